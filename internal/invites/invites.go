@@ -0,0 +1,80 @@
+// Package invites persists Tweetfleet invite requests so moderators can
+// approve or deny them asynchronously from the mod channel.
+package invites
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Status tracks where an invite request is in the mod-approval flow.
+type Status string
+
+const (
+	StatusPending  Status = "pending"
+	StatusApproved Status = "approved"
+	StatusDenied   Status = "denied"
+)
+
+// Invite is a single request to join the Tweetfleet workspace.
+type Invite struct {
+	ID            string
+	Email         string
+	CharacterName string
+	Status        Status
+	ModChannelTS  string
+	RequestedAt   time.Time
+	ActionedAt    *time.Time
+	ActionedBy    string
+}
+
+// ErrNotFound is returned when no invite exists for a given ID.
+var ErrNotFound = errors.New("invite not found")
+
+type Store interface {
+	Create(invite Invite) error
+	Get(id string) (Invite, error)
+	Update(invite Invite) error
+}
+
+type memoryStore struct {
+	mu      sync.Mutex
+	invites map[string]Invite
+}
+
+// NewMemoryStore builds an in-memory Store, the default until the
+// invite flow earns a durable backing store of its own.
+func NewMemoryStore() Store {
+	return &memoryStore{
+		invites: make(map[string]Invite),
+	}
+}
+
+func (m *memoryStore) Create(invite Invite) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.invites[invite.ID] = invite
+	return nil
+}
+
+func (m *memoryStore) Get(id string) (Invite, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	invite, ok := m.invites[id]
+	if !ok {
+		return Invite{}, ErrNotFound
+	}
+	return invite, nil
+}
+
+func (m *memoryStore) Update(invite Invite) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.invites[invite.ID]; !ok {
+		return ErrNotFound
+	}
+	m.invites[invite.ID] = invite
+	return nil
+}
@@ -0,0 +1,113 @@
+// Package scim implements just enough of Slack's SCIM API to invite a
+// user into the workspace, replacing the retired users.admin.invite
+// endpoint.
+package scim
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+const usersPath = "/scim/v1/Users"
+
+// Client creates users against Slack's SCIM API using a bearer token
+// minted for a workspace admin OAuth install.
+type Client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+func NewClient(baseURL, token string) *Client {
+	return &Client{
+		baseURL: baseURL,
+		token:   token,
+		http:    http.DefaultClient,
+	}
+}
+
+type name struct {
+	Formatted string `json:"formatted"`
+}
+
+type emailAddress struct {
+	Value   string `json:"value"`
+	Primary bool   `json:"primary"`
+}
+
+type createUserRequest struct {
+	Schemas  []string       `json:"schemas"`
+	UserName string         `json:"userName"`
+	Name     name           `json:"name"`
+	Emails   []emailAddress `json:"emails"`
+}
+
+type errorResponse struct {
+	Detail string `json:"detail"`
+	Status string `json:"status"`
+}
+
+// Error wraps a SCIM error response, exposing the HTTP status so
+// callers can branch on the well-known cases (409 duplicate user, 400
+// invalid email) without parsing the response body themselves.
+type Error struct {
+	Status int
+	Detail string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("scim: %d: %s", e.Status, e.Detail)
+}
+
+func IsDuplicate(err error) bool {
+	e, ok := err.(*Error)
+	return ok && e.Status == http.StatusConflict
+}
+
+func IsInvalidEmail(err error) bool {
+	e, ok := err.(*Error)
+	return ok && e.Status == http.StatusBadRequest
+}
+
+func (c *Client) CreateUser(email, realName string) error {
+
+	body := createUserRequest{
+		Schemas:  []string{"urn:ietf:params:scim:schemas:core:2.0:User"},
+		UserName: email,
+		Name:     name{Formatted: realName},
+		Emails:   []emailAddress{{Value: email, Primary: true}},
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal scim create user request")
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.baseURL+usersPath, bytes.NewReader(data))
+	if err != nil {
+		return errors.Wrap(err, "failed to build scim request")
+	}
+	req.Header.Set("Content-Type", "application/scim+json")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to make scim request")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	var scimErr errorResponse
+	if err := json.NewDecoder(resp.Body).Decode(&scimErr); err != nil {
+		return &Error{Status: resp.StatusCode, Detail: "unrecognized scim error response"}
+	}
+
+	return &Error{Status: resp.StatusCode, Detail: scimErr.Detail}
+}
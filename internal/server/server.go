@@ -0,0 +1,65 @@
+package server
+
+import (
+	"regexp"
+
+	"github.com/eveisesi/eb2/internal/invites"
+	"github.com/eveisesi/eb2/internal/scim"
+	"github.com/eveisesi/eb2/slack"
+	nslack "github.com/nlopes/slack"
+	"github.com/sirupsen/logrus"
+)
+
+// Config holds the server package's runtime configuration.
+type Config struct {
+	EveCallback     string
+	EveClientID     string
+	EveClientSecret string
+
+	SlackModChannel    string
+	SlackSigningSecret string
+
+	// SlackClientDNHeader, when set (e.g. "X-SSL-Client-DN"), requires
+	// that an mTLS-terminating proxy in front of /slack populate it with
+	// the client certificate's subject DN, which must match one of the
+	// SlackClientDNAllowlist patterns before the HMAC check runs.
+	SlackClientDNHeader    string
+	SlackClientDNAllowlist []string
+}
+
+type server struct {
+	config  Config
+	goslack *nslack.Client
+	logger  logrus.FieldLogger
+	slack   *slack.Service
+
+	invites invites.Store
+	scim    *scim.Client
+
+	// dnAllowlist is config.SlackClientDNAllowlist precompiled once at
+	// startup, rather than on every /slack request.
+	dnAllowlist []*regexp.Regexp
+}
+
+func New(config Config, client *nslack.Client, logger logrus.FieldLogger, svc *slack.Service, inviteStore invites.Store, scimClient *scim.Client) *server {
+
+	dnAllowlist := make([]*regexp.Regexp, 0, len(config.SlackClientDNAllowlist))
+	for _, pattern := range config.SlackClientDNAllowlist {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			logger.WithError(err).WithField("pattern", pattern).Error("invalid SlackClientDNAllowlist pattern, ignoring it")
+			continue
+		}
+		dnAllowlist = append(dnAllowlist, re)
+	}
+
+	return &server{
+		config:      config,
+		goslack:     client,
+		logger:      logger,
+		slack:       svc,
+		invites:     inviteStore,
+		scim:        scimClient,
+		dnAllowlist: dnAllowlist,
+	}
+}
@@ -2,16 +2,21 @@ package server
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"regexp"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
+	"github.com/eveisesi/eb2/internal/invites"
+	"github.com/eveisesi/eb2/internal/scim"
 	"github.com/eveisesi/eb2/pkg/tools"
+	"github.com/eveisesi/eb2/slack"
 	nslack "github.com/nlopes/slack"
 	"github.com/nlopes/slack/slackevents"
 	"github.com/patrickmn/go-cache"
@@ -19,13 +24,18 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+const (
+	actionInviteApprove = "invite_approve"
+	actionInviteDeny    = "invite_deny"
+)
+
 func (s *server) handlePostSlack(w http.ResponseWriter, r *http.Request) {
 
 	var ctx = r.Context()
 
-	err := verifySlackReqeust(r, s.config.SlackSigningSecret)
+	err := s.verifySlackReqeust(r)
 	if err != nil {
-		s.writeError(ctx, w, err, http.StatusBadRequest)
+		s.handleSlackVerificationError(ctx, w, err)
 		return
 	}
 
@@ -67,6 +77,90 @@ func (s *server) handlePostSlack(w http.ResponseWriter, r *http.Request) {
 
 }
 
+// handlePostSlackCommand handles slash command invocations (/esi, /tq,
+// etc). It shares verifySlackReqeust with the Events API handler and
+// feeds the parsed command into the same ProcessEvent pipeline via a
+// slack.CommandEvent.
+func (s *server) handlePostSlackCommand(w http.ResponseWriter, r *http.Request) {
+
+	var ctx = r.Context()
+
+	err := s.verifySlackReqeust(r)
+	if err != nil {
+		s.handleSlackVerificationError(ctx, w, err)
+		return
+	}
+
+	command, err := nslack.SlashCommandParse(r)
+	if err != nil {
+		s.writeError(ctx, w, err, http.StatusBadRequest)
+		return
+	}
+
+	event := slack.CommandEvent{
+		Command:     command.Command,
+		Text:        command.Text,
+		ChannelID:   command.ChannelID,
+		UserID:      command.UserID,
+		ResponseURL: command.ResponseURL,
+		TriggerID:   command.TriggerID,
+	}
+
+	go s.slack.ProcessEvent(ctx, event)
+
+	s.writeSuccess(ctx, w, nil, http.StatusOK)
+
+}
+
+// handlePostSlackInteractive handles Interactive Components payloads
+// carrying block actions (e.g. a click on a status message's "Refresh"
+// button). Only block actions with a registered handler are acted on;
+// any other payload, dialog submissions included, is acknowledged and
+// logged as unhandled. It shares verifySlackReqeust with the Events API
+// handler.
+func (s *server) handlePostSlackInteractive(w http.ResponseWriter, r *http.Request) {
+
+	var ctx = r.Context()
+
+	err := s.verifySlackReqeust(r)
+	if err != nil {
+		s.handleSlackVerificationError(ctx, w, err)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		s.writeError(ctx, w, errors.Wrap(err, "failed to parse interactive payload form"), http.StatusBadRequest)
+		return
+	}
+
+	var callback nslack.InteractionCallback
+	err = json.Unmarshal([]byte(r.FormValue("payload")), &callback)
+	if err != nil {
+		s.writeError(ctx, w, errors.Wrap(err, "failed to decode interactive payload"), http.StatusBadRequest)
+		return
+	}
+
+	go func(callback nslack.InteractionCallback) {
+
+		for _, action := range callback.ActionCallback.BlockActions {
+			if action.ActionID == actionInviteApprove || action.ActionID == actionInviteDeny {
+				s.handleInviteAction(ctx, callback, action)
+				return
+			}
+		}
+
+		if err := s.slack.HandleInteraction(ctx, callback); err != nil {
+			s.logger.WithError(err).WithFields(logrus.Fields{
+				"channel": callback.Channel.ID,
+				"action":  callback.Type,
+			}).Error("failed to handle slack interaction")
+		}
+	}(callback)
+
+	s.writeSuccess(ctx, w, nil, http.StatusOK)
+
+}
+
 var (
 	stateMap = cache.New(time.Minute*5, time.Minute*5)
 )
@@ -202,6 +296,7 @@ func (s *server) handlePostSlackInviteSend(w http.ResponseWriter, r *http.Reques
 			Ok:    false,
 			Error: "email_invalid: please supply a valid, non-empty email address",
 		})
+		return
 	}
 
 	check := ctx.Value(tokenKey)
@@ -223,8 +318,28 @@ func (s *server) handlePostSlackInviteSend(w http.ResponseWriter, r *http.Reques
 		Message string `json:"message"`
 	}
 
+	invite := invites.Invite{
+		ID:            tools.RandomString(16),
+		Email:         body.Email,
+		CharacterName: realName,
+		Status:        invites.StatusPending,
+		RequestedAt:   time.Now(),
+	}
+
+	approve := nslack.NewButtonBlockElement(actionInviteApprove, invite.ID, nslack.NewTextBlockObject(nslack.PlainTextType, "Approve", false, false))
+	approve.Style = nslack.StylePrimary
+	deny := nslack.NewButtonBlockElement(actionInviteDeny, invite.ID, nslack.NewTextBlockObject(nslack.PlainTextType, "Deny", false, false))
+	deny.Style = nslack.StyleDanger
+
 	msg := fmt.Sprintf("%s (%s) has requested an invitation to Tweetfleet.", realName, body.Email)
-	channel, timestamp, err := s.goslack.PostMessage(s.config.SlackModChannel, nslack.MsgOptionText(msg, false))
+	channel, timestamp, err := s.goslack.PostMessage(
+		s.config.SlackModChannel,
+		nslack.MsgOptionText(msg, false),
+		nslack.MsgOptionBlocks(
+			nslack.NewSectionBlock(nslack.NewTextBlockObject(nslack.MarkdownType, msg, false, false), nil, nil),
+			nslack.NewActionBlock("", approve, deny),
+		),
+	)
 	if err != nil {
 		s.logger.WithError(err).WithFields(logrus.Fields{
 			"channel":   channel,
@@ -235,59 +350,93 @@ func (s *server) handlePostSlackInviteSend(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	invite.ModChannelTS = timestamp
+	if err := s.invites.Create(invite); err != nil {
+		s.logger.WithError(err).WithField("email", body.Email).Error("failed to persist invite request")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
 	json.NewEncoder(w).Encode(message{
 		Message: "Your request has been submitted successfully. Please monitor your inbox for an invitation for the Tweetfleet Staff. Thank You",
 	})
 	w.WriteHeader(http.StatusOK)
-	return
-
-	// endpoint := "https://slack.com/api/users.admin.invite"
-
-	// uri := url.Values{}
-	// uri.Set("token", s.config.SlackLegacyAPIToken)
-	// uri.Set("email", body.Email)
-	// uri.Set("real_name", realName)
-
-	// resp, err := http.PostForm(endpoint, uri)
-	// if err != nil {
-	// 	s.writeError(ctx, w, err, http.StatusInternalServerError)
-	// 	return
-	// }
-
-	// var slackResp = &SlackInviteResponse{}
-	// err = json.NewDecoder(resp.Body).Decode(slackResp)
-	// if err != nil {
-	// 	s.writeError(ctx, w, errors.Wrap(err, "unable to decode response from slack"), http.StatusInternalServerError)
-	// 	return
-	// }
-
-	// status := http.StatusOK
-
-	// switch slackResp.Ok {
-	// case true:
-
-	// case false:
-	// 	status = http.StatusBadRequest
-	// 	msg := fmt.Sprintf("Uh Oh, I'm having issues inviting %s (%s) to TF Slack. Slack Response Dump: %s", realName, body.Email, slackResp.Error)
-	// 	channel, timestamp, err := s.goslack.PostMessage(s.config.SlackModChannel, nslack.MsgOptionText(msg, false))
-	// 	if err != nil {
-	// 		s.logger.WithError(err).WithFields(logrus.Fields{
-	// 			"channel":   channel,
-	// 			"timestamp": timestamp,
-	// 			"message":   msg,
-	// 		}).Error("failed to post message to mod chat.")
-	// 	}
-	// }
-
-	// data, _ := json.Marshal(slackResp)
-
-	// w.WriteHeader(status)
-	// _, _ = w.Write(data)
 
 }
 
-func verifySlackReqeust(req *http.Request, secret string) error {
-	verifier, err := nslack.NewSecretsVerifier(req.Header, secret)
+// handleInviteAction is called when a moderator clicks the Approve or
+// Deny button on an invite request posted to the mod channel. Approval
+// invites the requester into the workspace via SCIM; either action
+// updates the invite record and the original mod-channel message. If
+// the SCIM call fails, the record stays pending and only the message is
+// updated, with the failure.
+func (s *server) handleInviteAction(ctx context.Context, callback nslack.InteractionCallback, action *nslack.BlockAction) {
+
+	invite, err := s.invites.Get(action.Value)
+	if err != nil {
+		s.logger.WithError(err).WithField("inviteID", action.Value).Error("failed to load invite for moderation action")
+		return
+	}
+
+	moderator := callback.User.Name
+	now := time.Now()
+	invite.ActionedBy = moderator
+	invite.ActionedAt = &now
+
+	var footer string
+	switch action.ActionID {
+	case actionInviteDeny:
+		invite.Status = invites.StatusDenied
+		footer = fmt.Sprintf("Denied by %s", moderator)
+
+	case actionInviteApprove:
+		err := s.scim.CreateUser(invite.Email, invite.CharacterName)
+		switch {
+		case err == nil, scim.IsDuplicate(err):
+			invite.Status = invites.StatusApproved
+			footer = fmt.Sprintf("Approved by %s", moderator)
+		default:
+			s.logger.WithError(err).WithField("email", invite.Email).Error("failed to invite user via scim")
+			s.updateInviteMessage(ctx, callback, invite, fmt.Sprintf("Failed to invite %s: %s", invite.Email, err.Error()))
+			return
+		}
+	}
+
+	if err := s.invites.Update(invite); err != nil {
+		s.logger.WithError(err).WithField("inviteID", invite.ID).Error("failed to persist invite moderation decision")
+		return
+	}
+
+	s.updateInviteMessage(ctx, callback, invite, footer)
+}
+
+func (s *server) updateInviteMessage(ctx context.Context, callback nslack.InteractionCallback, invite invites.Invite, footer string) {
+	text := fmt.Sprintf("%s (%s) has requested an invitation to Tweetfleet. %s", invite.CharacterName, invite.Email, footer)
+	_, _, _, err := s.goslack.UpdateMessage(callback.Channel.ID, callback.MessageTs, nslack.MsgOptionText(text, false))
+	if err != nil {
+		s.logger.WithError(err).WithField("inviteID", invite.ID).Error("failed to update invite message in mod chat")
+	}
+}
+
+// errMTLSVerification marks failures of the optional client-certificate
+// DN check, so callers can answer those with 401 instead of the 400
+// used for a bad/missing HMAC signature.
+var errMTLSVerification = errors.New("client certificate verification failed")
+
+// verifySlackReqeust authenticates an inbound Slack request. When
+// s.config.SlackClientDNHeader is set, it first requires that header to
+// carry a subject DN matching s.config.SlackClientDNAllowlist, so Slack
+// events only flow through an mTLS-terminating proxy even if the
+// signing secret leaks. It then verifies the standard HMAC signature.
+func (s *server) verifySlackReqeust(req *http.Request) error {
+
+	if s.config.SlackClientDNHeader != "" {
+		if err := verifyClientDN(req, s.config.SlackClientDNHeader, s.dnAllowlist); err != nil {
+			return err
+		}
+	}
+
+	verifier, err := nslack.NewSecretsVerifier(req.Header, s.config.SlackSigningSecret)
 	if err != nil {
 		return errors.Wrap(err, "failed to create secrets verifier")
 	}
@@ -311,3 +460,30 @@ func verifySlackReqeust(req *http.Request, secret string) error {
 
 	return nil
 }
+
+func verifyClientDN(req *http.Request, header string, allowlist []*regexp.Regexp) error {
+
+	dn := req.Header.Get(header)
+	if dn == "" {
+		return errors.Wrapf(errMTLSVerification, "missing %s header", header)
+	}
+
+	for _, re := range allowlist {
+		if re.MatchString(dn) {
+			return nil
+		}
+	}
+
+	return errors.Wrapf(errMTLSVerification, "client certificate DN %q is not in the allowlist", dn)
+}
+
+// handleSlackVerificationError answers a failed verifySlackReqeust with
+// 401 for an mTLS DN mismatch and 400 for everything else (missing or
+// invalid HMAC signature).
+func (s *server) handleSlackVerificationError(ctx context.Context, w http.ResponseWriter, err error) {
+	status := http.StatusBadRequest
+	if errors.Cause(err) == errMTLSVerification {
+		status = http.StatusUnauthorized
+	}
+	s.writeError(ctx, w, err, status)
+}
@@ -0,0 +1,56 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestVerifyClientDN(t *testing.T) {
+	allowlist := []*regexp.Regexp{
+		regexp.MustCompile(`^CN=bot\.internal,O=esi-bot$`),
+	}
+
+	tests := []struct {
+		name    string
+		header  string
+		wantErr bool
+	}{
+		{name: "missing header", header: "", wantErr: true},
+		{name: "non-matching DN", header: "CN=someone-else,O=not-us", wantErr: true},
+		{name: "matching DN", header: "CN=bot.internal,O=esi-bot", wantErr: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/slack", nil)
+			if tt.header != "" {
+				req.Header.Set("X-SSL-Client-DN", tt.header)
+			}
+
+			err := verifyClientDN(req, "X-SSL-Client-DN", allowlist)
+			if tt.wantErr && err == nil {
+				t.Fatal("expected an error, got nil")
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestNewSkipsMalformedAllowlistPatterns(t *testing.T) {
+	config := Config{
+		SlackClientDNHeader:    "X-SSL-Client-DN",
+		SlackClientDNAllowlist: []string{`^CN=bot\.internal$`, `(unclosed`},
+	}
+
+	s := New(config, nil, logrus.New(), nil, nil, nil)
+
+	if len(s.dnAllowlist) != 1 {
+		t.Fatalf("expected 1 compiled pattern, got %d", len(s.dnAllowlist))
+	}
+}
@@ -0,0 +1,12 @@
+package slack
+
+// CommandEvent represents a Slack slash command invocation (e.g.
+// "/esi status", "/tq uptime").
+type CommandEvent struct {
+	Command     string
+	Text        string
+	ChannelID   string
+	UserID      string
+	ResponseURL string
+	TriggerID   string
+}
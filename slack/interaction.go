@@ -0,0 +1,74 @@
+package slack
+
+import (
+	"context"
+
+	nslack "github.com/nlopes/slack"
+	"github.com/pkg/errors"
+)
+
+const (
+	// ActionRefreshESIStatus is the block action ID on the "Refresh"
+	// button attached to an ESI status message. Its Value carries the
+	// version the message was rendered for.
+	ActionRefreshESIStatus = "refresh_esi_status"
+
+	ActionRefreshServerStatus = "refresh_server_status"
+)
+
+var errUnhandledAction = errors.New("no handler registered for this action")
+
+type actionHandler func(s *service, action *nslack.BlockAction) (MessageTemplate, error)
+
+// InteractionHandler dispatches block-action callbacks (e.g. a click on
+// a "Refresh" button) to the handler registered for their action ID.
+type InteractionHandler struct {
+	actions map[string]actionHandler
+}
+
+func NewInteractionHandler() *InteractionHandler {
+	return &InteractionHandler{
+		actions: map[string]actionHandler{
+			ActionRefreshESIStatus: func(s *service, action *nslack.BlockAction) (MessageTemplate, error) {
+				version := action.Value
+				if version == "" {
+					version = "latest"
+				}
+				return s.buildESIStatusMessage(version)
+			},
+			ActionRefreshServerStatus: func(s *service, action *nslack.BlockAction) (MessageTemplate, error) {
+				return s.buildEveServerStatusMessage()
+			},
+		},
+	}
+}
+
+func (h *InteractionHandler) Handle(ctx context.Context, s *service, callback nslack.InteractionCallback) error {
+
+	for _, action := range callback.ActionCallback.BlockActions {
+		handler, ok := h.actions[action.ActionID]
+		if !ok {
+			continue
+		}
+
+		template, err := handler(s, action)
+		if err != nil {
+			return errors.Wrap(err, "failed to rebuild message for action "+action.ActionID)
+		}
+
+		_, _, _, err = s.goslack.UpdateMessage(callback.Channel.ID, callback.MessageTs, template.options()...)
+		if err != nil {
+			return errors.Wrap(err, "failed to update original message")
+		}
+
+		return nil
+	}
+
+	return errUnhandledAction
+}
+
+var interactions = NewInteractionHandler()
+
+func (s *service) HandleInteraction(ctx context.Context, callback nslack.InteractionCallback) error {
+	return interactions.Handle(ctx, s, callback)
+}
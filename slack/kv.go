@@ -0,0 +1,38 @@
+package slack
+
+import "sync"
+
+// KV is the minimal persistent key/value interface the status watcher
+// needs for its snapshot, subscription, and uptime-sample state. It's
+// deliberately small so it can be backed by BoltDB, Redis, or anything
+// else - callers provide an implementation when constructing a
+// StatusWatcher.
+type KV interface {
+	Get(key string) ([]byte, bool, error)
+	Set(key string, value []byte) error
+}
+
+// memoryKV is an in-process KV with no durability across restarts. It's
+// the default until the watcher earns a durable backing store of its own.
+type memoryKV struct {
+	mu   sync.Mutex
+	data map[string][]byte
+}
+
+func NewMemoryKV() KV {
+	return &memoryKV{data: make(map[string][]byte)}
+}
+
+func (m *memoryKV) Get(key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	value, found := m.data[key]
+	return value, found, nil
+}
+
+func (m *memoryKV) Set(key string, value []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.data[key] = value
+	return nil
+}
@@ -0,0 +1,45 @@
+package slack
+
+import (
+	nslack "github.com/nlopes/slack"
+	"github.com/sirupsen/logrus"
+)
+
+// Config holds the slack package's runtime configuration.
+type Config struct {
+	// Username, IconEmoji, and IconURL are the service-wide defaults
+	// used when a command has no override configured for itself.
+	// IconEmoji takes priority over IconURL when both are set.
+	Username  string
+	IconEmoji string
+	IconURL   string
+
+	CommandOverrides map[string]CommandTemplateConfig
+
+	// Transport selects which runtime dispatches events: TransportHTTP
+	// (the default, used when empty) or TransportSocket. Read from the
+	// SLACK_TRANSPORT env var.
+	Transport string
+}
+
+// Service is the exported name for this package's shared handler type,
+// so other packages (e.g. server) can hold a reference to one without
+// reaching into its unexported fields.
+type Service = service
+
+type service struct {
+	goslack *nslack.Client
+	logger  logrus.FieldLogger
+	config  Config
+
+	// watcher is nil until NewStatusWatcher is called with this service.
+	watcher *StatusWatcher
+}
+
+func NewService(client *nslack.Client, logger logrus.FieldLogger, config Config) *service {
+	return &service{
+		goslack: client,
+		logger:  logger,
+		config:  config,
+	}
+}
@@ -0,0 +1,76 @@
+package slack
+
+import (
+	"context"
+
+	nslack "github.com/nlopes/slack"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// TransportHTTP is the default: Slack delivers events to a
+	// publicly reachable /slack endpoint registered by the server
+	// package.
+	TransportHTTP = "http"
+
+	// TransportSocket runs SocketMode instead. Selected via
+	// SLACK_TRANSPORT.
+	TransportSocket = "socket"
+)
+
+func UseSocketMode(cfg Config) bool {
+	return cfg.Transport == TransportSocket
+}
+
+// SocketMode runs the bot over a managed Slack RTM websocket connection
+// instead of the HTTP Events endpoint, so deployments behind NAT don't
+// need to publicly host /slack.
+//
+// This is RTM, not Slack's newer Socket Mode protocol (apps.connections.open
+// plus envelope/ack framing) - github.com/nlopes/slack, the fork this
+// bot is pinned to, never picked up Socket Mode support, and RTM is the
+// closest thing it offers to "no publicly hosted endpoint." If/when we
+// move off nlopes/slack this should be rewritten against the real
+// Socket Mode API.
+type SocketMode struct {
+	service *service
+	rtm     *nslack.RTM
+	logger  logrus.FieldLogger
+}
+
+func NewSocketMode(svc *service, client *nslack.Client, logger logrus.FieldLogger) *SocketMode {
+	return &SocketMode{
+		service: svc,
+		rtm:     client.NewRTM(),
+		logger:  logger,
+	}
+}
+
+func (m *SocketMode) Run(ctx context.Context) {
+
+	go m.rtm.ManageConnection()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-m.rtm.IncomingEvents:
+			if !ok {
+				return
+			}
+			m.handle(ctx, event)
+		}
+	}
+}
+
+func (m *SocketMode) handle(ctx context.Context, event nslack.RTMEvent) {
+
+	switch e := event.Data.(type) {
+	case *nslack.MessageEvent:
+		m.service.ProcessEvent(ctx, e)
+	case *nslack.RTMError:
+		m.logger.WithError(e).Error("rtm connection error")
+	case *nslack.InvalidAuthEvent:
+		m.logger.Error("rtm connection failed: invalid auth")
+	}
+}
@@ -34,19 +34,51 @@ var categories = []StatusCategory{
 	},
 }
 
+// greenCategory isn't in categories because, unlike red/yellow, we don't
+// want to dump every healthy route into the message - just the overall
+// count and health percentage.
+var greenCategory = StatusCategory{
+	Status: "green",
+	Emoji:  ":white_check_mark:",
+	Color:  "good",
+}
+
 var statusCache = cache.New(time.Minute*1, time.Second*30)
 
 func (s *service) makeEveServerStatusMessage(event Event) {
 
+	template, err := s.buildEveServerStatusMessage()
+	if err != nil {
+		_, _, _ = s.goslack.PostMessage(event.origin.Channel, nslack.MsgOptionText(err.Error(), true))
+		return
+	}
+
+	s.logger.Info("Responding to request for eve server status")
+	channel, timestamp, err := s.postTemplate(event.origin.Channel, "server", template)
+	if err != nil {
+		s.logger.WithError(err).Error("failed to respond to request for eve server status.")
+		return
+	}
+	s.logger.WithFields(logrus.Fields{
+		"channel":   channel,
+		"timestamp": timestamp,
+	}).Info("successfully responded to request for eve server status")
+}
+
+// buildEveServerStatusMessage fetches the current Tranquility status and
+// renders it as a MessageTemplate, without posting it anywhere. Shared
+// by the "server status" command and the interactive "Refresh" action.
+func (s *service) buildEveServerStatusMessage() (MessageTemplate, error) {
+
 	uri, _ := url.Parse(eb2.ESI_BASE)
 	uri.Path = "/v1/status"
 
 	resp, err := http.Get(uri.String())
 	if err != nil {
-		_, _, _ = s.goslack.PostMessage(event.origin.Channel, nslack.MsgOptionText(err.Error(), true))
-		return
+		return MessageTemplate{}, err
 	}
 	defer resp.Body.Close()
+
 	var attachment nslack.Attachment
 	if resp.StatusCode > 200 {
 
@@ -70,30 +102,17 @@ func (s *service) makeEveServerStatusMessage(event Event) {
 			}
 		}
 
-		s.logger.Info("Responding to request for eve server status")
-		channel, timestamp, err := s.goslack.PostMessage(event.origin.Channel, nslack.MsgOptionAttachments(attachment))
-		if err != nil {
-			s.logger.WithError(err).Error("failed to respond to request for eve server status.")
-			return
-		}
-		s.logger.WithFields(logrus.Fields{
-			"channel":   channel,
-			"timestamp": timestamp,
-		}).Info("successfully responded to request for eve server status")
-		return
-
+		return MessageTemplate{Attachments: []nslack.Attachment{attachment}, Blocks: serverRefreshBlocks()}, nil
 	}
 
 	data, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		_, _, _ = s.goslack.PostMessage(event.origin.Channel, nslack.MsgOptionText(err.Error(), true))
-		return
+		return MessageTemplate{}, err
 	}
 	var status eb2.ServerStatus
 	err = json.Unmarshal(data, &status)
 	if err != nil {
-		_, _, _ = s.goslack.PostMessage(event.origin.Channel, nslack.MsgOptionText(err.Error(), true))
-		return
+		return MessageTemplate{}, err
 	}
 	color := "good"
 	inVip := ""
@@ -123,16 +142,12 @@ func (s *service) makeEveServerStatusMessage(event Event) {
 		Fallback: fmt.Sprintf("%s status: %d player online, started at %s%s", "Tranquility", status.Players, status.StartTime.Format(layoutESI), inVip),
 	}
 
-	s.logger.Info("Responding to request for eve server status")
-	channel, timestamp, err := s.goslack.PostMessage(event.origin.Channel, nslack.MsgOptionAttachments(attachment))
-	if err != nil {
-		s.logger.WithError(err).Error("failed to respond to request for eve server status.")
-		return
-	}
-	s.logger.WithFields(logrus.Fields{
-		"channel":   channel,
-		"timestamp": timestamp,
-	}).Info("successfully responded to request for eve server status")
+	return MessageTemplate{Attachments: []nslack.Attachment{attachment}, Blocks: serverRefreshBlocks()}, nil
+}
+
+func serverRefreshBlocks() []nslack.Block {
+	refresh := nslack.NewButtonBlockElement(ActionRefreshServerStatus, "refresh", nslack.NewTextBlockObject(nslack.PlainTextType, "Refresh", false, false))
+	return []nslack.Block{nslack.NewActionBlock("", refresh)}
 }
 
 func determineServerRunTime(from time.Time) string {
@@ -181,13 +196,79 @@ func (s *service) makeESIStatusMessage(event Event) {
 		version = event.flags["version"]
 	}
 
+	template, err := s.buildESIStatusMessage(version)
+	if err != nil {
+		_, _, _ = s.goslack.PostMessage(event.origin.Channel, nslack.MsgOptionText(err.Error(), true))
+		return
+	}
+
+	s.logger.Info("Responding to request for esi route status.")
+	channel, timestamp, err := s.postTemplate(event.origin.Channel, "esi", template)
+	if err != nil {
+		s.logger.WithError(err).Error("failed to respond to request for esi route status.")
+		return
+	}
+	s.logger.WithFields(logrus.Fields{
+		"channel":   channel,
+		"timestamp": timestamp,
+	}).Info("successfully responded to request for esi route status.")
+
+}
+
+func (s *service) makeESISubscribeCommand(event Event) {
+
+	channel := event.flags["channel"]
+	severity := event.flags["severity"]
+
+	if channel == "" || (severity != "red" && severity != "yellow") {
+		_, _, _ = s.goslack.PostMessage(event.origin.Channel, nslack.MsgOptionText("usage: /esi subscribe #channel [red|yellow]", true))
+		return
+	}
+
+	if err := s.watcher.Subscribe(channel, severity); err != nil {
+		s.logger.WithError(err).Error("failed to subscribe channel to esi status")
+		_, _, _ = s.goslack.PostMessage(event.origin.Channel, nslack.MsgOptionText(err.Error(), true))
+		return
+	}
+
+	msg := fmt.Sprintf("%s is now subscribed to `%s` ESI status incidents.", channel, severity)
+	_, _, _ = s.goslack.PostMessage(event.origin.Channel, nslack.MsgOptionText(msg, false))
+}
+
+func (s *service) makeESIUptimeMessage(event Event) {
+
+	uptime, err := s.watcher.Uptime()
+	if err != nil {
+		s.logger.WithError(err).Error("failed to compute esi uptime")
+		_, _, _ = s.goslack.PostMessage(event.origin.Channel, nslack.MsgOptionText(err.Error(), true))
+		return
+	}
+
+	attachment := nslack.Attachment{
+		Color: "good",
+		Title: "ESI Uptime (last 24h)",
+		Text:  fmt.Sprintf("%.1f%% green", uptime),
+	}
+
+	_, _, err = s.postTemplate(event.origin.Channel, "esi", MessageTemplate{Attachments: []nslack.Attachment{attachment}})
+	if err != nil {
+		s.logger.WithError(err).Error("failed to respond to request for esi uptime")
+	}
+}
+
+// buildESIStatusMessage fetches (or reuses the cached) route statuses
+// for version and renders them as a MessageTemplate, without posting it
+// anywhere. Shared by the "esi status" command and the interactive
+// "Refresh" action.
+func (s *service) buildESIStatusMessage(version string) (MessageTemplate, error) {
+
 	routes, found := checkCache(version)
 	if !found {
 
-		routes, err := fetchRouteStatuses(version)
+		var err error
+		routes, err = fetchRouteStatuses(version)
 		if err != nil {
-			_, _, _ = s.goslack.PostMessage(event.origin.Channel, nslack.MsgOptionText(err.Error(), true))
-			return
+			return MessageTemplate{}, err
 		}
 
 		statusCache.Flush()
@@ -233,23 +314,41 @@ func (s *service) makeESIStatusMessage(event Event) {
 		}
 
 	}
-	if len(attachments) == 0 {
-		attachments = append(attachments, nslack.Attachment{
-			Text: ":ok_hand:",
-		})
+
+	green := 0
+	for _, route := range routes {
+		if route.Status == greenCategory.Status {
+			green++
+		}
 	}
 
-	s.logger.Info("Responding to request for esi route status.")
-	channel, timestamp, err := s.goslack.PostMessage(event.origin.Channel, nslack.MsgOptionAttachments(attachments...))
-	if err != nil {
-		s.logger.WithError(err).Error("failed to respond to request for esi route status.")
-		return
+	summary := nslack.Attachment{
+		Color: greenCategory.Color,
+		Fallback: fmt.Sprintf(
+			"%d out of %d routes green, %.1f%%",
+			green,
+			len(routes),
+			percentage(green, len(routes)),
+		),
+		Text: fmt.Sprintf(
+			"%s %d/%d routes green (%.1f%%) %s",
+			greenCategory.Emoji,
+			green,
+			len(routes),
+			percentage(green, len(routes)),
+			greenCategory.Emoji,
+		),
 	}
-	s.logger.WithFields(logrus.Fields{
-		"channel":   channel,
-		"timestamp": timestamp,
-	}).Info("successfully responded to request for esi route status.")
+	attachments = append(attachments, summary)
+
+	return MessageTemplate{Attachments: attachments, Blocks: esiRefreshBlocks(version)}, nil
+}
 
+// esiRefreshBlocks carries version as the button's value so the
+// interaction handler re-renders the same version on refresh.
+func esiRefreshBlocks(version string) []nslack.Block {
+	refresh := nslack.NewButtonBlockElement(ActionRefreshESIStatus, version, nslack.NewTextBlockObject(nslack.PlainTextType, "Refresh", false, false))
+	return []nslack.Block{nslack.NewActionBlock("", refresh)}
 }
 
 func checkCache(version string) ([]*eb2.ESIStatus, bool) {
@@ -264,7 +363,7 @@ func percentage(top int, bottom int) float64 {
 	if bottom == 0 {
 		return 0.00
 	}
-	return 1 - ((float64(top) / float64(bottom)) * 100)
+	return (float64(top) / float64(bottom)) * 100
 }
 
 func generateRoutesString(routes []*eb2.ESIStatus) string {
@@ -0,0 +1,11 @@
+package slack
+
+import "testing"
+
+func TestPercentage(t *testing.T) {
+	got := percentage(487, 490)
+	want := 99.4
+	if diff := got - want; diff < -0.05 || diff > 0.05 {
+		t.Errorf("percentage(487, 490) = %v, want ~%v", got, want)
+	}
+}
@@ -0,0 +1,89 @@
+package slack
+
+import (
+	"strings"
+
+	nslack "github.com/nlopes/slack"
+)
+
+// CommandTemplateConfig holds per-command presentation overrides, e.g.
+// giving the "server" command a different bot name/icon than "esi".
+type CommandTemplateConfig struct {
+	Username  string
+	IconEmoji string
+	IconURL   string
+}
+
+// Icon is auto-detected as an emoji (e.g. ":fire:") when it starts with
+// a colon, and treated as an image URL otherwise.
+type MessageTemplate struct {
+	Text        string
+	Attachments []nslack.Attachment
+	Blocks      []nslack.Block
+
+	Username string
+	Icon     string
+}
+
+func (s *service) resolveTemplate(command string, t MessageTemplate) MessageTemplate {
+
+	if t.Username == "" {
+		t.Username = s.config.Username
+	}
+	if t.Icon == "" {
+		t.Icon = s.config.IconEmoji
+		if t.Icon == "" {
+			t.Icon = s.config.IconURL
+		}
+	}
+
+	override, ok := s.config.CommandOverrides[command]
+	if !ok {
+		return t
+	}
+
+	if override.Username != "" {
+		t.Username = override.Username
+	}
+	if override.IconEmoji != "" {
+		t.Icon = override.IconEmoji
+	} else if override.IconURL != "" {
+		t.Icon = override.IconURL
+	}
+
+	return t
+}
+
+func (t MessageTemplate) options() []nslack.MsgOption {
+
+	opts := make([]nslack.MsgOption, 0, 4)
+
+	if len(t.Attachments) > 0 {
+		opts = append(opts, nslack.MsgOptionAttachments(t.Attachments...))
+	} else if t.Text != "" {
+		opts = append(opts, nslack.MsgOptionText(t.Text, true))
+	}
+
+	if len(t.Blocks) > 0 {
+		opts = append(opts, nslack.MsgOptionBlocks(t.Blocks...))
+	}
+
+	if t.Username != "" {
+		opts = append(opts, nslack.MsgOptionUsername(t.Username))
+	}
+
+	switch {
+	case t.Icon == "":
+	case strings.HasPrefix(t.Icon, ":"):
+		opts = append(opts, nslack.MsgOptionIconEmoji(t.Icon))
+	default:
+		opts = append(opts, nslack.MsgOptionIconURL(t.Icon))
+	}
+
+	return opts
+}
+
+func (s *service) postTemplate(channel, command string, t MessageTemplate) (string, string, error) {
+	t = s.resolveTemplate(command, t)
+	return s.goslack.PostMessage(channel, t.options()...)
+}
@@ -0,0 +1,423 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/eveisesi/eb2"
+	nslack "github.com/nlopes/slack"
+	"github.com/patrickmn/go-cache"
+	"github.com/pkg/errors"
+)
+
+const (
+	kvKeySnapshot      = "esi:status:snapshot"
+	kvKeySubscriptions = "esi:status:subscriptions"
+	kvKeyUptimeSamples = "esi:status:uptime_samples"
+
+	uptimeWindow = 24 * time.Hour
+)
+
+// StatusWatcher polls status.json on an interval, diffs it against the
+// previously observed snapshot, and proactively posts incident/
+// resolution summaries to channels subscribed via "/esi subscribe". It
+// also keeps a rolling window of samples so "/esi uptime" can report
+// percent-green over the last 24h.
+type StatusWatcher struct {
+	service *service
+	kv      KV
+
+	interval time.Duration
+
+	// threshold is how many routes must transition into red/yellow
+	// within window before an incident summary is posted.
+	threshold int
+	window    time.Duration
+
+	// cooldown bounds how often the same (route, method, transition)
+	// can trigger another post.
+	cooldown time.Duration
+	seen     *cache.Cache
+
+	// recent accumulates degrading transitions across polls so
+	// reportTransitions can count how many landed within window, not
+	// just the latest poll-to-poll diff. Only ever touched from Run's
+	// poll loop, so it needs no locking.
+	recent []degradation
+}
+
+type degradation struct {
+	at         time.Time
+	transition routeTransition
+}
+
+// NewStatusWatcher attaches itself to svc so the command handlers can
+// reach it via svc.watcher - callers still need to start it with Run.
+func NewStatusWatcher(svc *service, kv KV, interval time.Duration) *StatusWatcher {
+	w := &StatusWatcher{
+		service:   svc,
+		kv:        kv,
+		interval:  interval,
+		threshold: 3,
+		window:    5 * time.Minute,
+		cooldown:  15 * time.Minute,
+		seen:      cache.New(15*time.Minute, time.Minute),
+	}
+	svc.watcher = w
+	return w
+}
+
+func (w *StatusWatcher) Run(ctx context.Context) {
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := w.poll(); err != nil {
+				w.service.logger.WithError(err).Error("status watcher poll failed")
+			}
+		}
+	}
+}
+
+type routeTransition struct {
+	Method string
+	Route  string
+	From   string
+	To     string
+}
+
+func routeKey(method, route string) string {
+	return strings.ToUpper(method) + " " + route
+}
+
+func snapshotFrom(routes []*eb2.ESIStatus) map[string]string {
+	snapshot := make(map[string]string, len(routes))
+	for _, route := range routes {
+		snapshot[routeKey(route.Method, route.Route)] = route.Status
+	}
+	return snapshot
+}
+
+func diffSnapshots(previous, current map[string]string) []routeTransition {
+
+	var transitions []routeTransition
+	for key, to := range current {
+		from, ok := previous[key]
+		if !ok || from == to {
+			continue
+		}
+
+		parts := strings.SplitN(key, " ", 2)
+		transitions = append(transitions, routeTransition{
+			Method: parts[0],
+			Route:  parts[1],
+			From:   from,
+			To:     to,
+		})
+	}
+
+	return transitions
+}
+
+func isBad(status string) bool {
+	return status == "red" || status == "yellow"
+}
+
+func (w *StatusWatcher) poll() error {
+
+	routes, err := fetchRouteStatuses("latest")
+	if err != nil {
+		return errors.Wrap(err, "failed to fetch route statuses")
+	}
+
+	previous, err := w.loadSnapshot()
+	if err != nil {
+		return errors.Wrap(err, "failed to load previous status snapshot")
+	}
+
+	current := snapshotFrom(routes)
+
+	if err := w.recordUptimeSample(routes); err != nil {
+		w.service.logger.WithError(err).Error("failed to record uptime sample")
+	}
+
+	// The very first poll has nothing to diff against; just establish
+	// the baseline rather than reporting every route as a transition.
+	if len(previous) > 0 {
+		w.reportTransitions(diffSnapshots(previous, current))
+	}
+
+	return w.saveSnapshot(current)
+}
+
+// reportTransitions dedupes transitions, folds any fresh degradations
+// into the rolling window, and posts an incident once at least
+// threshold degradations have landed within window - not just within
+// this one poll's diff, so routes degrading one at a time across
+// several poll intervals still trip the threshold.
+func (w *StatusWatcher) reportTransitions(transitions []routeTransition) {
+
+	var degrading, recovering []routeTransition
+	for _, t := range transitions {
+		switch {
+		case isBad(t.To):
+			degrading = append(degrading, t)
+		case isBad(t.From) && t.To == "green":
+			recovering = append(recovering, t)
+		}
+	}
+
+	now := time.Now()
+	for _, t := range w.dedupe(degrading) {
+		w.recent = append(w.recent, degradation{at: now, transition: t})
+	}
+	w.recent = pruneOlderThan(w.recent, now.Add(-w.window))
+
+	if len(w.recent) >= w.threshold {
+		fresh := make([]routeTransition, len(w.recent))
+		for i, d := range w.recent {
+			fresh[i] = d.transition
+		}
+		w.postIncident(fresh)
+		w.recent = nil
+	}
+
+	for _, t := range w.dedupe(recovering) {
+		w.postResolution(t)
+	}
+}
+
+func pruneOlderThan(degradations []degradation, cutoff time.Time) []degradation {
+	pruned := degradations[:0]
+	for _, d := range degradations {
+		if d.at.After(cutoff) {
+			pruned = append(pruned, d)
+		}
+	}
+	return pruned
+}
+
+// dedupe drops any transition reported within the last cooldown window.
+func (w *StatusWatcher) dedupe(transitions []routeTransition) []routeTransition {
+
+	fresh := make([]routeTransition, 0, len(transitions))
+	for _, t := range transitions {
+		key := fmt.Sprintf("%s|%s|%s->%s", t.Method, t.Route, t.From, t.To)
+		if _, found := w.seen.Get(key); found {
+			continue
+		}
+		w.seen.Set(key, true, w.cooldown)
+		fresh = append(fresh, t)
+	}
+
+	return fresh
+}
+
+func (w *StatusWatcher) postIncident(transitions []routeTransition) {
+
+	for channel, matched := range w.channelTransitions(transitions) {
+
+		lines := make([]string, 0, len(matched))
+		for _, t := range matched {
+			lines = append(lines, fmt.Sprintf("%s %s %s -> %s", strings.ToUpper(t.Method), t.Route, t.From, t.To))
+		}
+
+		attachment := nslack.Attachment{
+			Color: "danger",
+			Title: fmt.Sprintf("ESI Incident: %d routes degraded", len(matched)),
+			Text:  fmt.Sprintf("```%s```", strings.Join(lines, "\n")),
+		}
+
+		_, _, err := w.service.postTemplate(channel, "esi", MessageTemplate{Attachments: []nslack.Attachment{attachment}})
+		if err != nil {
+			w.service.logger.WithError(err).WithField("channel", channel).Error("failed to post esi incident summary")
+		}
+	}
+}
+
+func (w *StatusWatcher) postResolution(t routeTransition) {
+
+	attachment := nslack.Attachment{
+		Color: "good",
+		Title: "ESI Incident Resolved",
+		Text:  fmt.Sprintf("%s %s recovered (%s -> green)", strings.ToUpper(t.Method), t.Route, t.From),
+	}
+
+	for channel := range w.channelTransitions([]routeTransition{t}) {
+		_, _, err := w.service.postTemplate(channel, "esi", MessageTemplate{Attachments: []nslack.Attachment{attachment}})
+		if err != nil {
+			w.service.logger.WithError(err).WithField("channel", channel).Error("failed to post esi resolution message")
+		}
+	}
+}
+
+// severityOf is the severity a subscription matches against: the
+// severity entered for a degrading transition, or recovered from for a
+// resolution.
+func severityOf(t routeTransition) string {
+	if isBad(t.To) {
+		return t.To
+	}
+	return t.From
+}
+
+// channelTransitions groups transitions by subscribed channel, keeping
+// only the transitions matching that channel's own subscribed
+// severities, so a channel subscribed to just "yellow" never receives
+// red transitions bundled into the same post.
+func (w *StatusWatcher) channelTransitions(transitions []routeTransition) map[string][]routeTransition {
+
+	subs, err := w.loadSubscriptions()
+	if err != nil {
+		w.service.logger.WithError(err).Error("failed to load esi status subscriptions")
+		return nil
+	}
+
+	grouped := make(map[string][]routeTransition)
+	for channel, severities := range subs {
+
+		allowed := make(map[string]bool, len(severities))
+		for _, severity := range severities {
+			allowed[severity] = true
+		}
+
+		var matched []routeTransition
+		for _, t := range transitions {
+			if allowed[severityOf(t)] {
+				matched = append(matched, t)
+			}
+		}
+
+		if len(matched) > 0 {
+			grouped[channel] = matched
+		}
+	}
+
+	return grouped
+}
+
+func (w *StatusWatcher) loadSnapshot() (map[string]string, error) {
+	var snapshot map[string]string
+	if err := w.getJSON(kvKeySnapshot, &snapshot); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+func (w *StatusWatcher) saveSnapshot(snapshot map[string]string) error {
+	return w.setJSON(kvKeySnapshot, snapshot)
+}
+
+func (w *StatusWatcher) loadSubscriptions() (map[string][]string, error) {
+	subs := make(map[string][]string)
+	if err := w.getJSON(kvKeySubscriptions, &subs); err != nil {
+		return nil, err
+	}
+	return subs, nil
+}
+
+// Subscribe opts channel in to incident/resolution posts for severity
+// ("red" or "yellow").
+func (w *StatusWatcher) Subscribe(channel, severity string) error {
+
+	subs, err := w.loadSubscriptions()
+	if err != nil {
+		return errors.Wrap(err, "failed to load esi status subscriptions")
+	}
+
+	for _, existing := range subs[channel] {
+		if existing == severity {
+			return nil
+		}
+	}
+
+	subs[channel] = append(subs[channel], severity)
+
+	return errors.Wrap(w.setJSON(kvKeySubscriptions, subs), "failed to persist esi status subscriptions")
+}
+
+type uptimeSample struct {
+	At    time.Time
+	Green int
+	Total int
+}
+
+func (w *StatusWatcher) recordUptimeSample(routes []*eb2.ESIStatus) error {
+
+	var samples []uptimeSample
+	if err := w.getJSON(kvKeyUptimeSamples, &samples); err != nil {
+		return err
+	}
+
+	green := 0
+	for _, route := range routes {
+		if route.Status == "green" {
+			green++
+		}
+	}
+
+	cutoff := time.Now().Add(-uptimeWindow)
+	pruned := samples[:0]
+	for _, sample := range samples {
+		if sample.At.After(cutoff) {
+			pruned = append(pruned, sample)
+		}
+	}
+
+	pruned = append(pruned, uptimeSample{At: time.Now(), Green: green, Total: len(routes)})
+
+	return w.setJSON(kvKeyUptimeSamples, pruned)
+}
+
+// Uptime returns the percentage of routes that were green across all
+// samples recorded in the last 24h.
+func (w *StatusWatcher) Uptime() (float64, error) {
+
+	var samples []uptimeSample
+	if err := w.getJSON(kvKeyUptimeSamples, &samples); err != nil {
+		return 0, err
+	}
+
+	var green, total int
+	for _, sample := range samples {
+		green += sample.Green
+		total += sample.Total
+	}
+
+	if total == 0 {
+		return 0, nil
+	}
+
+	return (float64(green) / float64(total)) * 100, nil
+}
+
+func (w *StatusWatcher) getJSON(key string, dest interface{}) error {
+
+	data, found, err := w.kv.Get(key)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return nil
+	}
+
+	return json.Unmarshal(data, dest)
+}
+
+func (w *StatusWatcher) setJSON(key string, value interface{}) error {
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	return w.kv.Set(key, data)
+}